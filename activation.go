@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ActivationType identifies an activation function that can be selected per layer.
+type ActivationType int
+
+const (
+	// ActivationSigmoid is the default squashing function used by earlier versions of this package.
+	ActivationSigmoid ActivationType = iota
+	ActivationTanh
+	ActivationReLU
+	ActivationLeakyReLU
+	// ActivationSoftmax turns a layer's raw scores into a probability distribution across the row;
+	// it is normally paired with LossCategoricalCrossEntropy on the output layer.
+	ActivationSoftmax
+)
+
+// leakyReLUAlpha is the slope applied to negative inputs by LeakyReLU and its derivative.
+const leakyReLUAlpha = 0.01
+
+// activationFuncs returns the elementwise activation function and its derivative for t. Softmax is
+// handled separately in ForwardPass since it operates across a whole row rather than elementwise; its
+// derivative here is a placeholder that BackwardPass never applies (see the Softmax+CCE shortcut).
+func activationFuncs(t ActivationType) (func(i, j int, v float64) float64, func(i, j int, v float64) float64) {
+	switch t {
+	case ActivationTanh:
+		return Tanh, TanhDerivative
+	case ActivationReLU:
+		return ReLU, ReLUDerivative
+	case ActivationLeakyReLU:
+		return LeakyReLU, LeakyReLUDerivative
+	case ActivationSoftmax:
+		return Sigmoid, softmaxGradientPlaceholder
+	default:
+		return Sigmoid, SigmoidDerivative
+	}
+}
+
+// Tanh is the hyperbolic tangent activation function f(x)
+func Tanh(_, _ int, v float64) float64 {
+	return math.Tanh(v)
+}
+
+// TanhDerivative is the derivative of Tanh f’(x)
+func TanhDerivative(_, _ int, v float64) float64 {
+	t := math.Tanh(v)
+	return 1 - t*t
+}
+
+// ReLU is the rectified linear unit activation function f(x)
+func ReLU(_, _ int, v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// ReLUDerivative is the derivative of ReLU f’(x)
+func ReLUDerivative(_, _ int, v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return 1
+}
+
+// LeakyReLU is the leaky rectified linear unit activation function f(x)
+func LeakyReLU(_, _ int, v float64) float64 {
+	if v < 0 {
+		return leakyReLUAlpha * v
+	}
+	return v
+}
+
+// LeakyReLUDerivative is the derivative of LeakyReLU f’(x)
+func LeakyReLUDerivative(_, _ int, v float64) float64 {
+	if v < 0 {
+		return leakyReLUAlpha
+	}
+	return 1
+}
+
+// softmaxGradientPlaceholder stands in for the full softmax Jacobian. BackwardPass never multiplies by
+// it: the Softmax+CategoricalCrossEntropy delta collapses to (activations - target) directly.
+func softmaxGradientPlaceholder(_, _ int, _ float64) float64 {
+	return 1
+}
+
+// Softmax applies the softmax function across a single row, turning raw scores into a probability
+// distribution that sums to 1. The max-subtraction keeps the exponentials numerically stable.
+func Softmax(row *mat.Dense) *mat.Dense {
+	_, cols := row.Dims()
+	out := mat.NewDense(1, cols, nil)
+
+	max := row.At(0, 0)
+	for j := 1; j < cols; j++ {
+		if v := row.At(0, j); v > max {
+			max = v
+		}
+	}
+
+	sum := 0.0
+	for j := 0; j < cols; j++ {
+		e := math.Exp(row.At(0, j) - max)
+		out.Set(0, j, e)
+		sum += e
+	}
+
+	for j := 0; j < cols; j++ {
+		out.Set(0, j, out.At(0, j)/sum)
+	}
+
+	return out
+}
@@ -0,0 +1,64 @@
+package main
+
+import "errors"
+
+// Callback observes training progress after every epoch and can request early termination by
+// returning stop=true from OnEpochEnd.
+type Callback interface {
+	OnEpochEnd(epoch int, trainLoss, valLoss float64) (stop bool)
+}
+
+// EarlyStopping stops training once the validation loss has gone Patience epochs in a row without
+// improving by at least MinDelta.
+type EarlyStopping struct {
+	Patience int
+	MinDelta float64
+
+	best        float64
+	badEpochs   int
+	initialized bool
+}
+
+// OnEpochEnd implements Callback.
+func (e *EarlyStopping) OnEpochEnd(_ int, _, valLoss float64) bool {
+	if !e.initialized || valLoss < e.best-e.MinDelta {
+		e.best = valLoss
+		e.initialized = true
+		e.badEpochs = 0
+		return false
+	}
+
+	e.badEpochs++
+	return e.badEpochs >= e.Patience
+}
+
+// ModelCheckpoint saves Network to Path after every epoch, or only when the validation loss improves
+// on the best seen so far if SaveBestOnly is set. LastError holds the result of the most recent save.
+type ModelCheckpoint struct {
+	Path         string
+	Network      *Network
+	SaveBestOnly bool
+	LastError    error
+
+	best        float64
+	initialized bool
+}
+
+// OnEpochEnd implements Callback.
+func (m *ModelCheckpoint) OnEpochEnd(_ int, _, valLoss float64) bool {
+	if m.Network == nil {
+		m.LastError = errors.New("nn: ModelCheckpoint.Network is nil")
+		return false
+	}
+
+	improved := !m.initialized || valLoss < m.best
+	if m.SaveBestOnly && !improved {
+		return false
+	}
+
+	m.best = valLoss
+	m.initialized = true
+	m.LastError = m.Network.SaveFile(m.Path)
+
+	return false
+}
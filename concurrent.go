@@ -0,0 +1,83 @@
+package main
+
+import (
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Context holds the per-sample forward/backward state (activations, dZ, and error delta for every
+// layer) for a single pass through the network. Keeping this off Layer is what lets multiple workers
+// evaluate the same network concurrently without racing on shared state.
+type Context struct {
+	Activations []*mat.Dense
+	Gradient    []*mat.Dense
+	Delta       []*mat.Dense
+}
+
+// newContext allocates an empty Context sized for a network with numberOfLayers layers.
+func newContext(numberOfLayers int) *Context {
+	return &Context{
+		Activations: make([]*mat.Dense, numberOfLayers),
+		Gradient:    make([]*mat.Dense, numberOfLayers),
+		Delta:       make([]*mat.Dense, numberOfLayers),
+	}
+}
+
+// scratchLayers returns a copy of network.Layers with fresh, zeroed gradient accumulators. Each worker
+// goroutine accumulates its shard of a mini-batch into its own scratch copy; Weights/Bias/activation
+// functions are shared pointers, but they are only ever read during a pass, never written.
+func (network *Network) scratchLayers() []Layer {
+	scratch := make([]Layer, len(network.Layers))
+	for i, layer := range network.Layers {
+		s := layer
+		s.resetGradients()
+		scratch[i] = s
+	}
+	return scratch
+}
+
+// computeSampleGradient runs one sample through Forward and BackwardPass, then accumulates its
+// contribution into dst (a scratch copy of network.Layers owned by a single worker).
+func (network *Network) computeSampleGradient(dst []Layer, input, target *mat.Dense) {
+	ctx := network.Forward(input)
+
+	lastLayer := network.Layers[network.NumberOfLayers-1]
+	for j := network.NumberOfLayers - 1; j >= 0; j-- {
+		network.Layers[j].BackwardPass(target, lastLayer, network.Loss, ctx, j)
+		lastLayer = network.Layers[j]
+	}
+
+	leftInput := input
+	for j := range network.Layers {
+		dst[j].AccumulateGradients(leftInput, ctx.Delta[j])
+		leftInput = ctx.Activations[j]
+	}
+}
+
+// computeBatchGradients splits rows across workers goroutines, each of which accumulates its shard of
+// the mini-batch into its own scratchLayers. It returns one scratch slice per worker for the caller
+// to sum (reduce) into the shared gradient accumulators before the weight update.
+func (network *Network) computeBatchGradients(batchInput, batchTarget *mat.Dense, rows []int, workers, inputCols, targetCols int) [][]Layer {
+	shards := splitRows(rows, workers)
+	results := make([][]Layer, len(shards))
+
+	var wg sync.WaitGroup
+	for s, shard := range shards {
+		wg.Add(1)
+		go func(s int, shard []int) {
+			defer wg.Done()
+
+			scratch := network.scratchLayers()
+			for _, i := range shard {
+				input := mat.NewDense(1, inputCols, batchInput.RawRowView(i))
+				target := mat.NewDense(1, targetCols, batchTarget.RawRowView(i))
+				network.computeSampleGradient(scratch, input, target)
+			}
+			results[s] = scratch
+		}(s, shard)
+	}
+	wg.Wait()
+
+	return results
+}
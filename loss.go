@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// LossType identifies the loss function used to compute the network's output-layer error.
+type LossType int
+
+const (
+	// LossMSE is mean squared error, the implicit loss used by earlier versions of this package.
+	LossMSE LossType = iota
+	// LossCrossEntropy is binary cross-entropy, intended for a single Sigmoid output neuron.
+	LossCrossEntropy
+	// LossCategoricalCrossEntropy is intended for a Softmax output layer over multiple classes.
+	LossCategoricalCrossEntropy
+)
+
+// lossEpsilon guards the cross-entropy losses against log(0) when a prediction saturates to 0 or 1.
+const lossEpsilon = 1e-12
+
+// ComputeLoss scores a single predicted row against its target row under the given loss function.
+func ComputeLoss(loss LossType, predicted, target *mat.Dense) float64 {
+	switch loss {
+	case LossCrossEntropy:
+		return binaryCrossEntropy(predicted, target)
+	case LossCategoricalCrossEntropy:
+		return categoricalCrossEntropy(predicted, target)
+	default:
+		return meanSquaredError(predicted, target)
+	}
+}
+
+// meanSquaredError is the average squared difference between predicted and target across the row.
+func meanSquaredError(predicted, target *mat.Dense) float64 {
+	_, cols := predicted.Dims()
+	sum := 0.0
+	for j := 0; j < cols; j++ {
+		d := predicted.At(0, j) - target.At(0, j)
+		sum += d * d
+	}
+	return sum / float64(cols)
+}
+
+// binaryCrossEntropy assumes a single Sigmoid output neuron per row.
+func binaryCrossEntropy(predicted, target *mat.Dense) float64 {
+	_, cols := predicted.Dims()
+	sum := 0.0
+	for j := 0; j < cols; j++ {
+		p := clampProbability(predicted.At(0, j))
+		y := target.At(0, j)
+		sum -= y*math.Log(p) + (1-y)*math.Log(1-p)
+	}
+	return sum / float64(cols)
+}
+
+// categoricalCrossEntropy assumes predicted is a Softmax probability distribution over the row.
+func categoricalCrossEntropy(predicted, target *mat.Dense) float64 {
+	_, cols := predicted.Dims()
+	sum := 0.0
+	for j := 0; j < cols; j++ {
+		p := clampProbability(predicted.At(0, j))
+		sum -= target.At(0, j) * math.Log(p)
+	}
+	return sum
+}
+
+// clampProbability keeps a probability away from the 0/1 boundaries where Log is undefined.
+func clampProbability(p float64) float64 {
+	if p < lossEpsilon {
+		return lossEpsilon
+	}
+	if p > 1-lossEpsilon {
+		return 1 - lossEpsilon
+	}
+	return p
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"math/rand"
+
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -9,156 +13,360 @@ type Network struct {
 	Layers          []Layer
 	NeuronsPerLayer int
 	NumberOfLayers  int
+	Loss            LossType // loss function used to compute the output layer's error
 }
 
 // Layer holds computational state to prevent reevaluating a needed variable more than once
 type Layer struct {
 	Bias                *mat.Dense
 	Weights             *mat.Dense
-	Delta               *mat.Dense                        // cumulative layer error delta (𝚫)
-	Gradient            *mat.Dense                        // gradient dZ (derivative of weighted outputs)
-	Activations         *mat.Dense                        // layer activations
+	Activation          ActivationType                    // which activation function this layer uses
 	ActivationFunc      func(i, j int, v float64) float64 // activation function f(x)
 	ActivationPrimeFunc func(i, j int, v float64) float64 // derivative of activation f’(x)
 	IsLastLayer         bool                              // boolean to show if current layer is output layer
+	WeightGrad          *mat.Dense                        // weight gradient accumulated across the current mini-batch
+	BiasGrad            *mat.Dense                        // bias gradient accumulated across the current mini-batch
+}
+
+// LayerConfig describes the size and activation function of a single layer.
+type LayerConfig struct {
+	Neurons    int
+	Activation ActivationType
 }
 
-// NewNetwork creates a new neural network object
+// ErrNoLayers is returned by NewNetworkWithConfig when layers is empty; there is no layer to derive
+// NeuronsPerLayer from, and a network with no layers can't forward-propagate anything.
+var ErrNoLayers = errors.New("nn: layers must not be empty")
+
+// validateLayerConfig rejects an empty layer list and any use of ActivationSoftmax other than on the last
+// layer paired with LossCategoricalCrossEntropy. ActivationSoftmax relies on the
+// Softmax+LossCategoricalCrossEntropy collapse in BackwardPass to produce a correct delta; anywhere else,
+// softmaxGradientPlaceholder silently stands in for the real Jacobian and trains on a wrong gradient.
+// Other activation/loss pairs are not checked: apart from Sigmoid+LossCrossEntropy, BackwardPass gives
+// them the MSE-style output delta (activations - target) * f'(z) whatever the loss.
+func validateLayerConfig(layers []LayerConfig, loss LossType) error {
+	if len(layers) == 0 {
+		return ErrNoLayers
+	}
+
+	for i, cfg := range layers {
+		if cfg.Activation != ActivationSoftmax {
+			continue
+		}
+		if i != len(layers)-1 {
+			return fmt.Errorf("nn: layer %d: ActivationSoftmax is only supported on the last layer", i)
+		}
+		if loss != LossCategoricalCrossEntropy {
+			return fmt.Errorf("nn: ActivationSoftmax must be paired with LossCategoricalCrossEntropy")
+		}
+	}
+
+	return nil
+}
+
+// NewNetwork creates a new neural network object using Sigmoid activations and MSE loss throughout
 func NewNetwork(neuronsPerLayer, numberOfHiddenLayers, numberOfInputs int) (*Network, error) {
-	network := new(Network)
-	network.Layers = make([]Layer, numberOfHiddenLayers+2)
+	layers := make([]LayerConfig, numberOfHiddenLayers+2)
+	for i := range layers {
+		layers[i] = LayerConfig{Neurons: neuronsPerLayer, Activation: ActivationSigmoid}
+	}
+	layers[len(layers)-1] = LayerConfig{Neurons: 1, Activation: ActivationSigmoid}
 
-	// Create input layer
-	inputWeights := KaimingInitialization(numberOfInputs, neuronsPerLayer)
-	inputLayer := layerConstructor(inputWeights, false)
-	network.Layers[0] = *inputLayer
+	return NewNetworkWithConfig(numberOfInputs, layers, LossMSE)
+}
 
-	// Populate hidden layers array
-	// Start after input, end right before output
-	for i := 1; i < numberOfHiddenLayers+1; i++ {
-		weights := KaimingInitialization(neuronsPerLayer, neuronsPerLayer)
-		temp := layerConstructor(weights, false)
-		network.Layers[i] = *temp
+// NewNetworkWithConfig creates a new neural network object with a per-layer activation function and a
+// network-level loss function, e.g. Softmax on the output layer paired with LossCategoricalCrossEntropy
+// for multi-class classification.
+func NewNetworkWithConfig(numberOfInputs int, layers []LayerConfig, loss LossType) (*Network, error) {
+	if err := validateLayerConfig(layers, loss); err != nil {
+		return nil, err
 	}
 
-	// Create the output layer (single neuron)
-	weights := KaimingInitialization(neuronsPerLayer, 1)
-	temp := layerConstructor(weights, true)
-	network.Layers[numberOfHiddenLayers+1] = *temp
+	network := new(Network)
+	network.Layers = make([]Layer, len(layers))
+	network.Loss = loss
+
+	// Populate layers array, wiring each layer's input size to the previous layer's neuron count
+	prevNeurons := numberOfInputs
+	for i, cfg := range layers {
+		weights := KaimingInitialization(prevNeurons, cfg.Neurons)
+		isLastLayer := i == len(layers)-1
+		network.Layers[i] = *layerConstructor(weights, isLastLayer, cfg.Activation)
+		prevNeurons = cfg.Neurons
+	}
 
 	// Set network information
-	network.NeuronsPerLayer = neuronsPerLayer
-	network.NumberOfLayers = numberOfHiddenLayers + 2
+	network.NeuronsPerLayer = layers[0].Neurons
+	network.NumberOfLayers = len(layers)
 
 	return network, nil
 }
 
 // layerConstructor is a private function that creates layers for the neural network object
-func layerConstructor(weights *mat.Dense, isLastLayer bool) *Layer {
+func layerConstructor(weights *mat.Dense, isLastLayer bool, activation ActivationType) *Layer {
 	layer := new(Layer)
 
 	// Set user inputs
 	layer.Weights = weights
 	layer.IsLastLayer = isLastLayer
+	layer.Activation = activation
 
 	// Set functions
-	layer.ActivationFunc = Sigmoid
-	layer.ActivationPrimeFunc = SigmoidDerivative
+	layer.ActivationFunc, layer.ActivationPrimeFunc = activationFuncs(activation)
 
 	// Create bias
 	_, cols := layer.Weights.Dims()
 	layer.Bias = mat.NewDense(1, cols, nil)
 
-	// Must create empty structs to avoid a nil pointer dereference when hard copying
-	layer.Delta = mat.NewDense(1, 1, nil)
-	layer.Gradient = mat.NewDense(1, 1, nil)
-	layer.Activations = mat.NewDense(1, 1, nil)
+	// Gradient accumulators share their dimensions with Weights/Bias; any optimizer-specific state
+	// (momentum velocity, Adam moments, ...) lives on the Optimizer itself, not on the Layer.
+	rows, _ := layer.Weights.Dims()
+	layer.WeightGrad = mat.NewDense(rows, cols, nil)
+	layer.BiasGrad = mat.NewDense(1, cols, nil)
 
 	return layer
 }
 
+// resetGradients zeroes out the layer's mini-batch gradient accumulators.
+func (layer *Layer) resetGradients() {
+	rows, cols := layer.Weights.Dims()
+	layer.WeightGrad = mat.NewDense(rows, cols, nil)
+	_, biasCols := layer.Bias.Dims()
+	layer.BiasGrad = mat.NewDense(1, biasCols, nil)
+}
+
+// AccumulateGradients adds this sample's contribution (leftA.T() ⋅ delta, delta) into the layer's
+// mini-batch gradient accumulators.
+func (layer *Layer) AccumulateGradients(leftA, delta *mat.Dense) {
+	weightGrad := Multiply(leftA.T(), delta)
+	layer.WeightGrad = Update(Add(layer.WeightGrad, weightGrad))
+	layer.BiasGrad = Update(Add(layer.BiasGrad, delta))
+}
+
 // Predict will run inputs through the network and return a probability
 func (network *Network) Predict(input *mat.Dense) *mat.Dense {
+	ctx := network.Forward(input)
+	return ctx.Activations[len(ctx.Activations)-1]
+}
+
+// Forward runs input through every layer and returns the resulting per-sample Context. It reads only
+// shared, immutable layer state (Weights, Bias, activation functions), so it's safe to call
+// concurrently for different samples of the same network.
+func (network *Network) Forward(input *mat.Dense) *Context {
+	ctx := newContext(len(network.Layers))
+
 	currentInput := input
-	for _, layer := range network.Layers {
-		layer.ForwardPass(currentInput)
-		currentInput = layer.Activations
+	for i := range network.Layers {
+		activations, gradient := network.Layers[i].ForwardPass(currentInput)
+		ctx.Activations[i] = activations
+		ctx.Gradient[i] = gradient
+		currentInput = activations
 	}
 
-	// strconv.ParseFloat(fmt.Sprintf("%.2f", v), 64)
-	return currentInput
+	return ctx
 }
 
-// ForwardPass is a single step function that sets a layer's state for Activations, and Gradient
-func (layer *Layer) ForwardPass(input *mat.Dense) {
+// ForwardPass computes this layer's activations and gradient (dZ) for input. It does not mutate the
+// layer, so the same Layer can be evaluated by multiple workers at once.
+func (layer *Layer) ForwardPass(input *mat.Dense) (activations, gradient *mat.Dense) {
 	z := Multiply(input, layer.Weights) // Z = Input ⋅ Weight
 	zB := Add(z, layer.Bias)            // Adding in the bias
 
-	// Hard copy struct pointers *a = *b
-	*layer.Activations = *Update(Map(layer.ActivationFunc, zB))   // Set activations
-	*layer.Gradient = *Update(Map(layer.ActivationPrimeFunc, zB)) // Set deriv. of the weighted outputs (dZ)
-}
+	// Softmax normalizes across the whole row, so it can't be expressed as the elementwise ActivationFunc
+	if layer.Activation == ActivationSoftmax {
+		return Update(Softmax(zB)), Update(Map(layer.ActivationPrimeFunc, zB))
+	}
 
-// Train will use Predict, BackwarPass and UpdateWeights to do back propagation on the network
-func (network *Network) Train(batchInput, batchTarget *mat.Dense, learningRate float64, epochs int) {
-	// Training cycles (how many times to repeat the batch)
-	lastLayer := network.Layers[network.NumberOfLayers-1]
-	for ; epochs > 0; epochs-- {
+	return Update(Map(layer.ActivationFunc, zB)), Update(Map(layer.ActivationPrimeFunc, zB))
+}
 
-		_, targetCols := batchTarget.Dims()
-		inputRows, inputCols := batchInput.Dims()
+// TrainConfig controls a single call to Network.Train: the mini-batch size, how many epochs to run,
+// the learning rate, the optimizer, and L1/L2 regularization strength.
+type TrainConfig struct {
+	BatchSize    int // rows per mini-batch; <= 1 falls back to online (per-row) SGD
+	Epochs       int // number of passes over the full input
+	LearningRate float64
+	Optimizer    Optimizer // weight-update rule; defaults to SGD{} if nil
+	L1           float64   // L1 (lasso) regularization strength
+	L2           float64   // L2 (ridge) regularization strength
+	Shuffle      bool      // reshuffle row order between epochs
+	Workers      int       // goroutines to split each mini-batch across; <= 1 runs it on a single worker goroutine
+
+	ValidationX, ValidationY *mat.Dense // held-out set scored after every epoch, if set
+	Callbacks                []Callback // invoked after every epoch; any returning true stops training
+}
 
-		for i := 0; i < inputRows; i++ {
-			// Extract input from batch
-			inputRowFloat := batchInput.RawRowView(i)
-			tempInput := mat.NewDense(1, inputCols, inputRowFloat)
+// Train will use Forward, BackwardPass and UpdateWeights to do mini-batch gradient descent with
+// L1/L2 regularization on the network, via config.Optimizer. Each mini-batch is split across
+// config.Workers goroutines; see computeSampleGradient for how they avoid racing on shared layer state.
+func (network *Network) Train(batchInput, batchTarget *mat.Dense, config TrainConfig) {
+	_, targetCols := batchTarget.Dims()
+	inputRows, inputCols := batchInput.Dims()
 
-			// Predict on given row to set state
-			network.Predict(tempInput)
+	batchSize := config.BatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if config.Optimizer == nil {
+		config.Optimizer = SGD{}
+	}
+	config.Optimizer.Init(network.Layers)
 
-			// Backwards Propogation
-			// Go backwards from the last layer
-			for j := network.NumberOfLayers - 1; j >= 0; j-- {
-				// Extract expected value from batch
-				targetRowFloat := batchTarget.RawRowView(i)
-				tempTarget := mat.NewDense(1, targetCols, targetRowFloat)
+	for epoch := 0; epoch < config.Epochs; epoch++ {
+		order := rowOrder(inputRows, config.Shuffle)
 
-				// Calculate gradient for current layer
-				network.Layers[j].BackwardPass(tempTarget, lastLayer)
-				lastLayer = network.Layers[j]
+		for start := 0; start < inputRows; start += batchSize {
+			end := start + batchSize
+			if end > inputRows {
+				end = inputRows
 			}
 
-			// Get initial input and start calculating the new weights
-			currInput := tempInput
-			for j := 0; j < len(network.Layers); j++ {
-				network.Layers[j].UpdateWeights(learningRate, currInput)
-				currInput = network.Layers[j].Activations
+			shardGrads := network.computeBatchGradients(batchInput, batchTarget, order[start:end], workers, inputCols, targetCols)
+
+			batchRows := end - start
+			for j := range network.Layers {
+				network.Layers[j].resetGradients()
+				for _, scratch := range shardGrads {
+					network.Layers[j].WeightGrad = Update(Add(network.Layers[j].WeightGrad, scratch[j].WeightGrad))
+					network.Layers[j].BiasGrad = Update(Add(network.Layers[j].BiasGrad, scratch[j].BiasGrad))
+				}
+				network.Layers[j].UpdateWeights(config, batchRows)
 			}
 		}
+
+		if network.runCallbacks(config, epoch, batchInput, batchTarget) {
+			break
+		}
+	}
+}
+
+// runCallbacks scores the epoch's train/validation loss and invokes config.Callbacks, returning true
+// if any callback requested that training stop.
+func (network *Network) runCallbacks(config TrainConfig, epoch int, batchInput, batchTarget *mat.Dense) bool {
+	if len(config.Callbacks) == 0 {
+		return false
+	}
+
+	trainLoss := network.evaluateLoss(batchInput, batchTarget)
+
+	// Fall back to trainLoss when no validation set is configured, so callbacks that compare valLoss
+	// against a running best (EarlyStopping, ModelCheckpoint) still see real progress instead of being
+	// pinned against a 0.0 sentinel that can never be "improved" on.
+	valLoss := trainLoss
+	if config.ValidationX != nil && config.ValidationY != nil {
+		valLoss = network.evaluateLoss(config.ValidationX, config.ValidationY)
+	}
+
+	stop := false
+	for _, cb := range config.Callbacks {
+		if cb.OnEpochEnd(epoch, trainLoss, valLoss) {
+			stop = true
+		}
+	}
+	return stop
+}
+
+// evaluateLoss averages the network's configured loss function across every row of x against y.
+func (network *Network) evaluateLoss(x, y *mat.Dense) float64 {
+	rows, cols := x.Dims()
+	_, targetCols := y.Dims()
+	if rows == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		input := mat.NewDense(1, cols, x.RawRowView(i))
+		target := mat.NewDense(1, targetCols, y.RawRowView(i))
+		sum += ComputeLoss(network.Loss, network.Predict(input), target)
+	}
+	return sum / float64(rows)
+}
+
+// rowOrder returns the row indices [0, n) in order, shuffled in place when shuffle is true.
+func rowOrder(n int, shuffle bool) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	if shuffle {
+		rand.Shuffle(n, func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	return order
+}
+
+// splitRows divides rows into up to n roughly equal shards for worker goroutines.
+func splitRows(rows []int, n int) [][]int {
+	if n > len(rows) {
+		n = len(rows)
+	}
+	if n < 1 {
+		return nil
+	}
+
+	shards := make([][]int, n)
+	for i, row := range rows {
+		shards[i%n] = append(shards[i%n], row)
 	}
+	return shards
 }
 
-// BackwardPass is a single step function that sets the layer's state for Delta
-func (layer *Layer) BackwardPass(target *mat.Dense, rightLayer Layer) {
+// BackwardPass computes this layer's error delta into ctx.Delta[index], reading this layer's
+// activations/gradient and the next layer's delta/weights out of ctx and rightLayer rather than
+// layer-local state, so the same Layer can be used by multiple workers at once.
+func (layer *Layer) BackwardPass(target *mat.Dense, rightLayer Layer, loss LossType, ctx *Context, index int) {
 	if layer.IsLastLayer {
-		err := Subtract(layer.Activations, target)
-		layer.Delta = Update(MultiplyElems(err, layer.Gradient))
+		err := Subtract(ctx.Activations[index], target)
+
+		// Softmax+categorical-cross-entropy and Sigmoid+binary-cross-entropy both collapse to
+		// (activations - target): in each case the output activation's Jacobian and the loss
+		// derivative cancel, so the gradient mask must be skipped rather than applied a second time.
+		if layer.Activation == ActivationSoftmax && loss == LossCategoricalCrossEntropy {
+			ctx.Delta[index] = Update(err)
+			return
+		}
+		if layer.Activation == ActivationSigmoid && loss == LossCrossEntropy {
+			ctx.Delta[index] = Update(err)
+			return
+		}
+
+		ctx.Delta[index] = Update(MultiplyElems(err, ctx.Gradient[index]))
 		return
 	}
 
-	layer.Delta = Update(MultiplyElems(Multiply(rightLayer.Delta, rightLayer.Weights.T()), layer.Gradient))
+	rightDelta := ctx.Delta[index+1]
+	ctx.Delta[index] = Update(MultiplyElems(Multiply(rightDelta, rightLayer.Weights.T()), ctx.Gradient[index]))
 }
 
-// UpdateWeights will calculate the correct new weights and apply them to a layer
-func (layer *Layer) UpdateWeights(learningRate float64, leftA *mat.Dense) {
-	// Calculate and update weights
-	ad := Multiply(leftA.T(), layer.Delta)
-	change := Scale(learningRate, ad)
-	layer.Weights = Update(Subtract(layer.Weights, change))
+// UpdateWeights averages the accumulated mini-batch gradient, folds in L1/L2 regularization on the
+// weights, and hands the result to config.Optimizer to actually update layer's weights and bias.
+func (layer *Layer) UpdateWeights(config TrainConfig, batchSize int) {
+	avgWeightGrad := Scale(1/float64(batchSize), layer.WeightGrad)
+	l2Term := Scale(config.L2, layer.Weights)
+	l1Term := Scale(config.L1, Map(sign, layer.Weights))
+	wGrad := Add(Add(avgWeightGrad, l2Term), l1Term)
 
-	// Calculate and update bias
-	dB := Scale(learningRate, layer.Delta)
-	layer.Bias = Update(Subtract(layer.Bias, dB))
+	// Regularization is not applied to the bias
+	bGrad := Scale(1/float64(batchSize), layer.BiasGrad)
+
+	config.Optimizer.Step(layer, wGrad, bGrad, config.LearningRate)
+}
+
+// sign returns the sign of v: -1, 0, or 1. Used to compute the L1 regularization gradient term.
+func sign(_, _ int, v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
 }
 
 // Print will display a formatted matrix
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Optimizer turns an averaged, regularized mini-batch gradient into a parameter update. It keeps its
+// own per-layer state (momentum velocity, Adam moments, ...) so Network and Layer stay free of any
+// one optimizer's bookkeeping.
+type Optimizer interface {
+	// Init prepares the optimizer to drive layers. It is called at the start of every Train call, but
+	// implementations must only allocate state the first time they see a given layers slice, so that
+	// state built up over earlier Train calls (e.g. momentum velocity) survives across calls.
+	Init(layers []Layer)
+	// Step updates layer's Weights and Bias in place from the averaged weight/bias gradients.
+	Step(layer *Layer, wGrad, bGrad *mat.Dense, lr float64)
+}
+
+// layerIndex maps each layer's address to its position, so an Optimizer's per-layer state slices
+// (sized and ordered the same way) can be looked up from just a *Layer in Step.
+func layerIndex(layers []Layer) map[*Layer]int {
+	index := make(map[*Layer]int, len(layers))
+	for i := range layers {
+		index[&layers[i]] = i
+	}
+	return index
+}
+
+// layerSet identifies which backing []Layer an Optimizer was last initialized for. Comparing length
+// alone isn't enough: two different *Network values with identical architectures have layer slices of
+// the same length but different layers, and re-initializing off length would let Init wrongly no-op,
+// leaving the optimizer's index still pointing at the first network's layers.
+type layerSet struct {
+	first *Layer
+	count int
+}
+
+// newLayerSet captures the identity of layers.
+func newLayerSet(layers []Layer) layerSet {
+	ls := layerSet{count: len(layers)}
+	if len(layers) > 0 {
+		ls.first = &layers[0]
+	}
+	return ls
+}
+
+// matches reports whether layers is the same backing slice this layerSet was built from.
+func (ls layerSet) matches(layers []Layer) bool {
+	if ls.count != len(layers) {
+		return false
+	}
+	if ls.count == 0 {
+		return true
+	}
+	return ls.first == &layers[0]
+}
+
+// SGD is plain gradient descent: W -= lr*grad. It holds no state.
+type SGD struct{}
+
+// Init implements Optimizer.
+func (SGD) Init([]Layer) {}
+
+// Step implements Optimizer.
+func (SGD) Step(layer *Layer, wGrad, bGrad *mat.Dense, lr float64) {
+	layer.Weights = Update(Subtract(layer.Weights, Scale(lr, wGrad)))
+	layer.Bias = Update(Subtract(layer.Bias, Scale(lr, bGrad)))
+}
+
+// Momentum carries a velocity term forward across steps: v = Beta*v + lr*grad, W -= v.
+type Momentum struct {
+	Beta float64
+
+	weightVelocity []*mat.Dense
+	biasVelocity   []*mat.Dense
+	index          map[*Layer]int
+	layers         layerSet
+}
+
+// Init implements Optimizer. It is a no-op if this optimizer has already been initialized for this
+// same layers slice, so velocity accumulated in an earlier Train call isn't reset by a later one.
+func (m *Momentum) Init(layers []Layer) {
+	if m.layers.matches(layers) {
+		return
+	}
+	m.layers = newLayerSet(layers)
+
+	m.weightVelocity = make([]*mat.Dense, len(layers))
+	m.biasVelocity = make([]*mat.Dense, len(layers))
+	m.index = layerIndex(layers)
+
+	for i := range layers {
+		rows, cols := layers[i].Weights.Dims()
+		m.weightVelocity[i] = mat.NewDense(rows, cols, nil)
+		_, biasCols := layers[i].Bias.Dims()
+		m.biasVelocity[i] = mat.NewDense(1, biasCols, nil)
+	}
+}
+
+// Step implements Optimizer.
+func (m *Momentum) Step(layer *Layer, wGrad, bGrad *mat.Dense, lr float64) {
+	i := m.index[layer]
+
+	m.weightVelocity[i] = Update(Add(Scale(m.Beta, m.weightVelocity[i]), Scale(lr, wGrad)))
+	layer.Weights = Update(Subtract(layer.Weights, m.weightVelocity[i]))
+
+	m.biasVelocity[i] = Update(Add(Scale(m.Beta, m.biasVelocity[i]), Scale(lr, bGrad)))
+	layer.Bias = Update(Subtract(layer.Bias, m.biasVelocity[i]))
+}
+
+// RMSProp divides the learning rate by a running average of the squared gradient, so parameters with
+// large, noisy gradients get smaller effective steps.
+type RMSProp struct {
+	Beta float64
+	Eps  float64
+
+	weightCache []*mat.Dense
+	biasCache   []*mat.Dense
+	index       map[*Layer]int
+	layers      layerSet
+}
+
+// Init implements Optimizer. It is a no-op if this optimizer has already been initialized for this
+// same layers slice, so the running squared-gradient average from an earlier Train call isn't reset.
+func (r *RMSProp) Init(layers []Layer) {
+	if r.layers.matches(layers) {
+		return
+	}
+	r.layers = newLayerSet(layers)
+
+	r.weightCache = make([]*mat.Dense, len(layers))
+	r.biasCache = make([]*mat.Dense, len(layers))
+	r.index = layerIndex(layers)
+
+	for i := range layers {
+		rows, cols := layers[i].Weights.Dims()
+		r.weightCache[i] = mat.NewDense(rows, cols, nil)
+		_, biasCols := layers[i].Bias.Dims()
+		r.biasCache[i] = mat.NewDense(1, biasCols, nil)
+	}
+}
+
+// Step implements Optimizer.
+func (r *RMSProp) Step(layer *Layer, wGrad, bGrad *mat.Dense, lr float64) {
+	i := r.index[layer]
+	layer.Weights = Update(Subtract(layer.Weights, rmsPropStep(r.weightCache[i], wGrad, r.Beta, r.Eps, lr)))
+	layer.Bias = Update(Subtract(layer.Bias, rmsPropStep(r.biasCache[i], bGrad, r.Beta, r.Eps, lr)))
+}
+
+// rmsPropStep updates cache in place from grad (cache = beta*cache + (1-beta)*grad²) and returns the
+// step lr*grad/(√cache + eps).
+func rmsPropStep(cache, grad *mat.Dense, beta, eps, lr float64) *mat.Dense {
+	rows, cols := grad.Dims()
+	step := mat.NewDense(rows, cols, nil)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			g := grad.At(i, j)
+			c := beta*cache.At(i, j) + (1-beta)*g*g
+			cache.Set(i, j, c)
+			step.Set(i, j, lr*g/(math.Sqrt(c)+eps))
+		}
+	}
+
+	return step
+}
+
+// Adam tracks bias-corrected first and second moments of the gradient per parameter:
+// m = Beta1*m + (1-Beta1)*g, v = Beta2*v + (1-Beta2)*g², W -= lr * m̂/(√v̂ + Eps).
+type Adam struct {
+	Beta1 float64
+	Beta2 float64
+	Eps   float64
+
+	weightM []*mat.Dense
+	weightV []*mat.Dense
+	biasM   []*mat.Dense
+	biasV   []*mat.Dense
+	step    []int
+	index   map[*Layer]int
+	layers  layerSet
+}
+
+// Init implements Optimizer. It is a no-op if this optimizer has already been initialized for this
+// same layers slice, so the moment estimates and step counters from an earlier Train call carry
+// forward.
+func (a *Adam) Init(layers []Layer) {
+	if a.layers.matches(layers) {
+		return
+	}
+	a.layers = newLayerSet(layers)
+
+	a.weightM = make([]*mat.Dense, len(layers))
+	a.weightV = make([]*mat.Dense, len(layers))
+	a.biasM = make([]*mat.Dense, len(layers))
+	a.biasV = make([]*mat.Dense, len(layers))
+	a.step = make([]int, len(layers))
+	a.index = layerIndex(layers)
+
+	for i := range layers {
+		rows, cols := layers[i].Weights.Dims()
+		a.weightM[i] = mat.NewDense(rows, cols, nil)
+		a.weightV[i] = mat.NewDense(rows, cols, nil)
+		_, biasCols := layers[i].Bias.Dims()
+		a.biasM[i] = mat.NewDense(1, biasCols, nil)
+		a.biasV[i] = mat.NewDense(1, biasCols, nil)
+	}
+}
+
+// Step implements Optimizer.
+func (a *Adam) Step(layer *Layer, wGrad, bGrad *mat.Dense, lr float64) {
+	i := a.index[layer]
+	a.step[i]++
+	t := float64(a.step[i])
+
+	layer.Weights = Update(Subtract(layer.Weights, adamStep(a.weightM[i], a.weightV[i], wGrad, a.Beta1, a.Beta2, a.Eps, lr, t)))
+	layer.Bias = Update(Subtract(layer.Bias, adamStep(a.biasM[i], a.biasV[i], bGrad, a.Beta1, a.Beta2, a.Eps, lr, t)))
+}
+
+// adamStep updates the first/second moment matrices m, v in place from grad and returns the
+// bias-corrected step lr * m̂/(√v̂ + eps) to subtract from the parameter.
+func adamStep(m, v, grad *mat.Dense, beta1, beta2, eps, lr, t float64) *mat.Dense {
+	rows, cols := grad.Dims()
+	step := mat.NewDense(rows, cols, nil)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			g := grad.At(i, j)
+
+			mIJ := beta1*m.At(i, j) + (1-beta1)*g
+			vIJ := beta2*v.At(i, j) + (1-beta2)*g*g
+			m.Set(i, j, mIJ)
+			v.Set(i, j, vIJ)
+
+			mHat := mIJ / (1 - math.Pow(beta1, t))
+			vHat := vIJ / (1 - math.Pow(beta2, t))
+
+			step.Set(i, j, lr*mHat/(math.Sqrt(vHat)+eps))
+		}
+	}
+
+	return step
+}
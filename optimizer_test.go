@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// TestAdamStepBiasCorrection checks Adam's first-step update against the textbook formula by hand:
+// with Beta1=0.9, Beta2=0.999, a single step's bias correction divides by (1-Beta1) and (1-Beta2)
+// exactly, so m̂ equals the gradient g and v̂ equals g², and the step is lr*g/|g|.
+func TestAdamStepBiasCorrection(t *testing.T) {
+	layers := []Layer{{
+		Weights: mat.NewDense(1, 1, []float64{1.0}),
+		Bias:    mat.NewDense(1, 1, []float64{0.0}),
+	}}
+
+	adam := &Adam{Beta1: 0.9, Beta2: 0.999, Eps: 1e-8}
+	adam.Init(layers)
+
+	wGrad := mat.NewDense(1, 1, []float64{2.0})
+	bGrad := mat.NewDense(1, 1, []float64{0.5})
+	adam.Step(&layers[0], wGrad, bGrad, 0.1)
+
+	const tolerance = 1e-6
+	if got, want := layers[0].Weights.At(0, 0), 0.9; math.Abs(got-want) > tolerance {
+		t.Errorf("Weights after one step = %v, want %v", got, want)
+	}
+	if got, want := layers[0].Bias.At(0, 0), -0.1; math.Abs(got-want) > tolerance {
+		t.Errorf("Bias after one step = %v, want %v", got, want)
+	}
+}
+
+// TestAdamResetsAcrossDifferentNetworks guards the layerSet identity fix: Init on a same-shaped but
+// distinct layers slice must rebuild state rather than silently reusing a stale index.
+func TestAdamResetsAcrossDifferentNetworks(t *testing.T) {
+	netA := []Layer{{
+		Weights: mat.NewDense(1, 1, []float64{1.0}),
+		Bias:    mat.NewDense(1, 1, []float64{0.0}),
+	}}
+	netB := []Layer{{
+		Weights: mat.NewDense(1, 1, []float64{1.0}),
+		Bias:    mat.NewDense(1, 1, []float64{0.0}),
+	}}
+
+	adam := &Adam{Beta1: 0.9, Beta2: 0.999, Eps: 1e-8}
+	adam.Init(netA)
+	adam.Init(netB)
+
+	if _, ok := adam.index[&netB[0]]; !ok {
+		t.Fatal("Init did not rebuild index for a different layers slice of the same shape")
+	}
+}
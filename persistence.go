@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// saveMagic identifies a file produced by Network.Save; Load rejects anything else up front.
+var saveMagic = [4]byte{'G', 'N', 'N', '1'}
+
+// saveVersion is bumped whenever the binary layout below changes in an incompatible way.
+const saveVersion uint32 = 1
+
+// ErrInvalidMagic is returned by Load when the input doesn't start with the expected magic bytes.
+var ErrInvalidMagic = errors.New("nn: not a valid network file")
+
+// ErrUnsupportedVersion is returned by Load when the file was written by an incompatible version.
+var ErrUnsupportedVersion = errors.New("nn: unsupported network file version")
+
+// ErrDimensionMismatch is returned by Load when a layer's declared dimensions are unreasonable, don't
+// leave enough data in the reader, or don't chain into the next layer's input size.
+var ErrDimensionMismatch = errors.New("nn: layer dimensions do not match stored data")
+
+// maxLayerElements bounds a single layer's declared rows*cols. It guards loadLayer's allocation
+// against a corrupted or malicious header claiming an enormous layer; 1<<26 (64M float64s, 512MB) is
+// comfortably larger than any layer this package is meant to train.
+const maxLayerElements = 1 << 26
+
+// Save writes the network's topology, per-layer activation, loss function, weights, and biases to w
+// using a versioned binary format.
+func (network *Network) Save(w io.Writer) error {
+	if _, err := w.Write(saveMagic[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, saveVersion); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(network.Loss)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(len(network.Layers))); err != nil {
+		return err
+	}
+
+	for i := range network.Layers {
+		if err := network.Layers[i].save(w); err != nil {
+			return fmt.Errorf("nn: saving layer %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// save writes a single layer's activation, dimensions, and raw weight/bias data to w.
+func (layer *Layer) save(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(layer.Activation)); err != nil {
+		return err
+	}
+
+	rows, cols := layer.Weights.Dims()
+	if err := binary.Write(w, binary.BigEndian, uint32(rows)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(cols)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, layer.Weights.RawMatrix().Data); err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.BigEndian, layer.Bias.RawMatrix().Data)
+}
+
+// SaveFile saves the network to the file at path, creating or truncating it as needed.
+func (network *Network) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return network.Save(f)
+}
+
+// Load reads a network previously written by Save. It returns ErrInvalidMagic, ErrUnsupportedVersion,
+// or ErrDimensionMismatch if the data is malformed.
+func Load(r io.Reader) (*Network, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != saveMagic {
+		return nil, ErrInvalidMagic
+	}
+
+	var version uint32
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != saveVersion {
+		return nil, ErrUnsupportedVersion
+	}
+
+	var loss uint32
+	if err := binary.Read(r, binary.BigEndian, &loss); err != nil {
+		return nil, err
+	}
+
+	var layerCount uint32
+	if err := binary.Read(r, binary.BigEndian, &layerCount); err != nil {
+		return nil, err
+	}
+
+	network := new(Network)
+	network.Loss = LossType(loss)
+	network.Layers = make([]Layer, layerCount)
+
+	for i := range network.Layers {
+		layer, err := loadLayer(r)
+		if err != nil {
+			return nil, fmt.Errorf("nn: loading layer %d: %w", i, err)
+		}
+		layer.IsLastLayer = i == int(layerCount)-1
+		network.Layers[i] = *layer
+	}
+
+	for i := 1; i < len(network.Layers); i++ {
+		_, prevCols := network.Layers[i-1].Weights.Dims()
+		rows, _ := network.Layers[i].Weights.Dims()
+		if prevCols != rows {
+			return nil, fmt.Errorf("nn: layer %d expects %d inputs but layer %d outputs %d: %w", i, rows, i-1, prevCols, ErrDimensionMismatch)
+		}
+	}
+
+	if layerCount > 0 {
+		_, cols := network.Layers[0].Weights.Dims()
+		network.NeuronsPerLayer = cols
+	}
+	network.NumberOfLayers = int(layerCount)
+
+	return network, nil
+}
+
+// loadLayer reads a single layer's activation, dimensions, and raw weight/bias data from r.
+func loadLayer(r io.Reader) (*Layer, error) {
+	var activation uint32
+	if err := binary.Read(r, binary.BigEndian, &activation); err != nil {
+		return nil, err
+	}
+
+	var rows, cols uint32
+	if err := binary.Read(r, binary.BigEndian, &rows); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &cols); err != nil {
+		return nil, err
+	}
+
+	elements := uint64(rows) * uint64(cols)
+	if rows == 0 || cols == 0 || elements > maxLayerElements {
+		return nil, fmt.Errorf("nn: layer declares %dx%d elements: %w", rows, cols, ErrDimensionMismatch)
+	}
+
+	const bytesPerFloat64 = 8
+	needed := (elements + uint64(cols)) * bytesPerFloat64
+	if seeker, ok := r.(io.Seeker); ok {
+		if err := checkRemaining(seeker, needed); err != nil {
+			return nil, err
+		}
+	}
+
+	weightData := make([]float64, elements)
+	if err := binary.Read(r, binary.BigEndian, weightData); err != nil {
+		return nil, err
+	}
+
+	biasData := make([]float64, cols)
+	if err := binary.Read(r, binary.BigEndian, biasData); err != nil {
+		return nil, err
+	}
+
+	layer := layerConstructor(mat.NewDense(int(rows), int(cols), weightData), false, ActivationType(activation))
+	layer.Bias = mat.NewDense(1, int(cols), biasData)
+
+	return layer, nil
+}
+
+// checkRemaining returns ErrDimensionMismatch if fewer than need bytes remain after seeker's current
+// position, without disturbing that position. It lets loadLayer reject a corrupted header (declaring
+// more data than the file actually holds) before it allocates anything sized by that header.
+func checkRemaining(seeker io.Seeker, need uint64) error {
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return err
+	}
+
+	if end < current || uint64(end-current) < need {
+		return fmt.Errorf("nn: layer declares more data than remains in the file: %w", ErrDimensionMismatch)
+	}
+	return nil
+}
+
+// LoadFile loads a network previously saved with SaveFile.
+func LoadFile(path string) (*Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
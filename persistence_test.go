@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// TestSaveLoadRoundTrip verifies that a network written with Save and read back with Load ends up with
+// identical topology, activation, loss, and weight/bias values to the original.
+func TestSaveLoadRoundTrip(t *testing.T) {
+	original, err := NewNetworkWithConfig(3, []LayerConfig{
+		{Neurons: 4, Activation: ActivationReLU},
+		{Neurons: 2, Activation: ActivationSigmoid},
+	}, LossMSE)
+	if err != nil {
+		t.Fatalf("NewNetworkWithConfig: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if loaded.Loss != original.Loss {
+		t.Fatalf("Loss = %v, want %v", loaded.Loss, original.Loss)
+	}
+	if len(loaded.Layers) != len(original.Layers) {
+		t.Fatalf("len(Layers) = %d, want %d", len(loaded.Layers), len(original.Layers))
+	}
+
+	for i := range original.Layers {
+		want, got := original.Layers[i], loaded.Layers[i]
+
+		if got.Activation != want.Activation {
+			t.Errorf("layer %d: Activation = %v, want %v", i, got.Activation, want.Activation)
+		}
+		if got.IsLastLayer != want.IsLastLayer {
+			t.Errorf("layer %d: IsLastLayer = %v, want %v", i, got.IsLastLayer, want.IsLastLayer)
+		}
+		if !mat.Equal(got.Weights, want.Weights) {
+			t.Errorf("layer %d: Weights did not round-trip exactly", i)
+		}
+		if !mat.Equal(got.Bias, want.Bias) {
+			t.Errorf("layer %d: Bias did not round-trip exactly", i)
+		}
+	}
+}
+
+// TestLoadRejectsInvalidMagic verifies that Load rejects input that doesn't start with saveMagic.
+func TestLoadRejectsInvalidMagic(t *testing.T) {
+	if _, err := Load(bytes.NewReader([]byte("not a network file"))); err != ErrInvalidMagic {
+		t.Fatalf("expected ErrInvalidMagic, got %v", err)
+	}
+}
@@ -28,7 +28,11 @@ func main() {
 	input := mat.NewDense(4, 2, numbers)
 	ans := mat.NewDense(4, 1, answers)
 
-	model.Train(input, ans, 0.5, 100000)
+	model.Train(input, ans, TrainConfig{
+		BatchSize:    4,
+		Epochs:       100000,
+		LearningRate: 0.5,
+	})
 
 	first := []float64{
 		0, 0,